@@ -0,0 +1,71 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2021-Present The Zarf Authors
+
+// Package hooks contains the mutation hooks for the Zarf agent.
+package hooks
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/defenseunicorns/zarf/src/internal/agent/operations"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	v1 "k8s.io/api/admission/v1"
+)
+
+// Admission adapts a Hook's Create/Update handlers into an http.Handler that speaks the Kubernetes
+// AdmissionReview protocol, so a Hook can be registered directly as a mutating webhook route.
+func Admission(hook operations.Hook) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		review := v1.AdmissionReview{}
+		if err := json.NewDecoder(r.Body).Decode(&review); err != nil {
+			message.Debugf("unable to decode the admission review request: %s", err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		req := review.Request
+
+		var result *operations.Result
+		var err error
+		switch req.Operation {
+		case v1.Create:
+			result, err = hook.Create(req)
+		case v1.Update:
+			result, err = hook.Update(req)
+		default:
+			http.Error(w, fmt.Sprintf("unsupported admission operation: %s", req.Operation), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			message.Debugf("hook returned an error: %s", err.Error())
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		response := v1.AdmissionReview{
+			TypeMeta: review.TypeMeta,
+			Response: &v1.AdmissionResponse{
+				UID:     req.UID,
+				Allowed: result.Allowed,
+			},
+		}
+
+		if len(result.PatchOps) > 0 {
+			patch, err := json.Marshal(result.PatchOps)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			patchType := v1.PatchTypeJSONPatch
+			response.Response.Patch = patch
+			response.Response.PatchType = &patchType
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(response); err != nil {
+			message.Debugf("unable to encode the admission review response: %s", err.Error())
+		}
+	})
+}
@@ -8,8 +8,8 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 
-	"github.com/defenseunicorns/pkg/helpers"
 	"github.com/defenseunicorns/zarf/src/config/lang"
 	"github.com/defenseunicorns/zarf/src/internal/agent/operations"
 	"github.com/defenseunicorns/zarf/src/pkg/cluster"
@@ -20,6 +20,9 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// ociRegistryPrefix is the scheme ArgoCD uses to reference a Helm chart stored in an OCI registry.
+const ociRegistryPrefix = "oci://"
+
 // Application is a definition of an ArgoCD Application resource.
 // The ArgoCD Application structs in this file have been partially copied from upstream.
 //
@@ -44,6 +47,31 @@ type ApplicationSpec struct {
 type ApplicationSource struct {
 	// RepoURL is the URL to the repository (Git or Helm) that contains the application manifests.
 	RepoURL string `json:"repoURL"`
+	// Chart is the name of the Helm chart, when the source points at a Helm or OCI registry instead of a Git repository.
+	Chart string `json:"chart,omitempty"`
+	// TargetRevision defines the revision of the source to sync the application to.
+	TargetRevision string `json:"targetRevision,omitempty"`
+	// Ref is a reference to another source within the same Application, used with `valueFiles: - $ref/values.yaml`.
+	Ref string `json:"ref,omitempty"`
+	// Helm holds Helm specific options, such as values file references.
+	Helm *ApplicationSourceHelm `json:"helm,omitempty"`
+}
+
+// ApplicationSourceHelm holds Helm specific options used when rendering a source.
+type ApplicationSourceHelm struct {
+	// ValueFiles is a list of Helm value files to use when generating a template, including `$ref`-prefixed
+	// paths that point at a value file living in another one of the Application's sources.
+	ValueFiles []string `json:"valueFiles,omitempty"`
+}
+
+// isHelmChartSource reports whether an ApplicationSource points at a Helm chart (as opposed to a Git repository).
+func (a ApplicationSource) isHelmChartSource() bool {
+	return a.Chart != ""
+}
+
+// isOCIRegistry reports whether the given repoURL is an OCI registry reference.
+func isOCIRegistry(repoURL string) bool {
+	return strings.HasPrefix(repoURL, ociRegistryPrefix)
 }
 
 // NewApplicationMutationHook creates a new instance of the ArgoCD Application mutation hook.
@@ -68,8 +96,15 @@ func mutateApplication(ctx context.Context, r *v1.AdmissionRequest, cluster *clu
 
 	message.Debugf("Using the url of (%s) to mutate the ArgoCD Application", state.GitServer.Address)
 
+	return computeApplicationPatches(ctx, r, state)
+}
+
+// computeApplicationPatches decodes the Application admission request and builds the JSON patches that
+// rewrite its source(s) to point at the Zarf git server / registry. Split out from mutateApplication so
+// it can be exercised directly in tests without needing a live cluster to load ZarfState from.
+func computeApplicationPatches(ctx context.Context, r *v1.AdmissionRequest, state *types.ZarfState) (*operations.Result, error) {
 	app := Application{}
-	if err = json.Unmarshal(r.Object.Raw, &app); err != nil {
+	if err := json.Unmarshal(r.Object.Raw, &app); err != nil {
 		return nil, fmt.Errorf(lang.ErrUnmarshal, err)
 	}
 
@@ -78,7 +113,7 @@ func mutateApplication(ctx context.Context, r *v1.AdmissionRequest, cluster *clu
 	patches := []operations.PatchOperation{}
 
 	if app.Spec.Source != nil {
-		patchedURL, err := getPatchedRepoURL(app.Spec.Source.RepoURL, state.GitServer, r)
+		patchedURL, err := getPatchedRepoURL(ctx, *app.Spec.Source, state, r)
 		if err != nil {
 			return nil, err
 		}
@@ -87,7 +122,7 @@ func mutateApplication(ctx context.Context, r *v1.AdmissionRequest, cluster *clu
 
 	if len(app.Spec.Sources) > 0 {
 		for idx, source := range app.Spec.Sources {
-			patchedURL, err := getPatchedRepoURL(source.RepoURL, state.GitServer, r)
+			patchedURL, err := getPatchedRepoURL(ctx, source, state, r)
 			if err != nil {
 				return nil, err
 			}
@@ -95,6 +130,9 @@ func mutateApplication(ctx context.Context, r *v1.AdmissionRequest, cluster *clu
 		}
 	}
 
+	// $ref-style valueFiles (e.g. "$ref/values.yaml") point at another source's `ref` name rather than a
+	// URL, so they are left untouched here -- the source they point at is still patched above like any other.
+
 	patches = append(patches, getLabelPatch(app.Labels))
 
 	return &operations.Result{
@@ -103,20 +141,67 @@ func mutateApplication(ctx context.Context, r *v1.AdmissionRequest, cluster *clu
 	}, nil
 }
 
-func getPatchedRepoURL(repoURL string, gs types.GitServerInfo, r *v1.AdmissionRequest) (string, error) {
+func getPatchedRepoURL(_ context.Context, source ApplicationSource, state *types.ZarfState, r *v1.AdmissionRequest) (string, error) {
+	if source.isHelmChartSource() {
+		return getPatchedHelmRepoURL(source.RepoURL, state, r)
+	}
+	return getPatchedGitRepoURL(source.RepoURL, state, r)
+}
+
+// getPatchedHelmRepoURL rewrites a Helm chart source's RepoURL. Only OCI registry references
+// (`oci://...`) are mirrored into the internal Zarf registry; classic Helm chart repos are left as-is
+// since Zarf does not maintain a mirror of arbitrary Helm repositories.
+func getPatchedHelmRepoURL(repoURL string, state *types.ZarfState, r *v1.AdmissionRequest) (string, error) {
+	if !isOCIRegistry(repoURL) {
+		return repoURL, nil
+	}
+
+	ri := state.RegistryInfo
+	isCreate := r.Operation == v1.Create
+	isUpdate := r.Operation == v1.Update
+	patchedURL := repoURL
+	var isPatched bool
+	var err error
+
+	// Use the same canonical already-transformed check as git Application sources, rather than a raw
+	// hostname comparison, so that mutation here stays idempotent too.
+	if isUpdate {
+		isPatched, err = transform.IsOCIRegistryRefTransformed(state, repoURL)
+		if err != nil {
+			return "", fmt.Errorf("unable to determine if the Helm OCI repoURL (%s) is already transformed: %w", repoURL, err)
+		}
+	}
+
+	if isCreate || (isUpdate && !isPatched) {
+		transformedRef, err := transform.ImageTransformHost(ri.Address, strings.TrimPrefix(repoURL, ociRegistryPrefix))
+		if err != nil {
+			return "", fmt.Errorf("%s: %w", AgentErrTransformGitURL, err)
+		}
+		patchedURL = ociRegistryPrefix + transformedRef
+		message.Debugf("original Helm OCI repoURL of (%s) got mutated to (%s)", repoURL, patchedURL)
+	}
+
+	return patchedURL, nil
+}
+
+func getPatchedGitRepoURL(repoURL string, state *types.ZarfState, r *v1.AdmissionRequest) (string, error) {
+	gs := state.GitServer
 	isCreate := r.Operation == v1.Create
 	isUpdate := r.Operation == v1.Update
 	patchedURL := repoURL
 	var isPatched bool
 	var err error
 
-	// Check if this is an update operation and the hostname is different from what we have in the zarfState
-	// NOTE: We mutate on updates IF AND ONLY IF the hostname in the request is different from the hostname in the zarfState
-	// NOTE: We are checking if the hostname is different before because we do not want to potentially mutate a URL that has already been mutated.
+	// Check if this is an update operation and the repoURL has already been transformed to point at the
+	// Zarf git server.
+	// NOTE: We mutate on updates IF AND ONLY IF the repoURL in the request has not already been transformed.
+	// NOTE: We check this before mutating because we do not want to re-mutate a URL that has already been
+	// mutated -- including a URL that ArgoCD's controller wrote back with a normalized trailing slash or
+	// `.git` suffix, which would otherwise be double-transformed on every reconcile.
 	if isUpdate {
-		isPatched, err = helpers.DoHostnamesMatch(gs.Address, repoURL)
+		isPatched, err = transform.IsGitURLTransformed(state, repoURL)
 		if err != nil {
-			return "", fmt.Errorf(lang.AgentErrHostnameMatch, err)
+			return "", fmt.Errorf("unable to determine if the repoURL (%s) is already transformed: %w", repoURL, err)
 		}
 	}
 
@@ -0,0 +1,228 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2021-Present The Zarf Authors
+
+package hooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/defenseunicorns/zarf/src/internal/agent/operations"
+	"github.com/defenseunicorns/zarf/src/types"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/admission/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+func testZarfState() *types.ZarfState {
+	return &types.ZarfState{
+		GitServer: types.GitServerInfo{
+			Address:      "https://git.localhost",
+			PushUsername: "zarf-git-user",
+		},
+		RegistryInfo: types.RegistryInfo{
+			Address: "registry.localhost",
+		},
+	}
+}
+
+// singleSourceHelmOCIFixture is an Application with a single Helm source pointing at an OCI registry.
+const singleSourceHelmOCIFixture = `{
+	"spec": {
+		"source": {
+			"repoURL": "oci://ghcr.io/defenseunicorns/charts",
+			"chart": "podinfo",
+			"targetRevision": "6.5.4"
+		}
+	}
+}`
+
+// multiSourceGitHelmFixture is an Application with one Git source and one Helm (OCI) source.
+const multiSourceGitHelmFixture = `{
+	"spec": {
+		"sources": [
+			{
+				"repoURL": "https://github.com/defenseunicorns/zarf.git",
+				"targetRevision": "main"
+			},
+			{
+				"repoURL": "oci://ghcr.io/defenseunicorns/charts",
+				"chart": "podinfo",
+				"targetRevision": "6.5.4"
+			}
+		]
+	}
+}`
+
+// valueFileRefFixture is an Application whose Helm source references a values file living in another
+// source via the "ref:"/"$ref" convention. The referenced source carries the "ref" field; the
+// valueFiles entry itself is just a path and is never a URL, so it must be left untouched.
+const valueFileRefFixture = `{
+	"spec": {
+		"sources": [
+			{
+				"repoURL": "https://github.com/defenseunicorns/zarf-values.git",
+				"ref": "values"
+			},
+			{
+				"repoURL": "oci://ghcr.io/defenseunicorns/charts",
+				"chart": "podinfo",
+				"helm": {
+					"valueFiles": ["$values/podinfo/values.yaml"]
+				}
+			}
+		]
+	}
+}`
+
+func TestApplicationSourceUnmarshal(t *testing.T) {
+	t.Run("single-source helm", func(t *testing.T) {
+		app := Application{}
+		require.NoError(t, json.Unmarshal([]byte(singleSourceHelmOCIFixture), &app))
+		require.NotNil(t, app.Spec.Source)
+		require.True(t, app.Spec.Source.isHelmChartSource())
+		require.Equal(t, "podinfo", app.Spec.Source.Chart)
+		require.Equal(t, "6.5.4", app.Spec.Source.TargetRevision)
+	})
+
+	t.Run("multi-source git+helm", func(t *testing.T) {
+		app := Application{}
+		require.NoError(t, json.Unmarshal([]byte(multiSourceGitHelmFixture), &app))
+		require.Len(t, app.Spec.Sources, 2)
+		require.False(t, app.Spec.Sources[0].isHelmChartSource())
+		require.True(t, app.Spec.Sources[1].isHelmChartSource())
+	})
+
+	t.Run("value-file ref layout", func(t *testing.T) {
+		app := Application{}
+		require.NoError(t, json.Unmarshal([]byte(valueFileRefFixture), &app))
+		require.Len(t, app.Spec.Sources, 2)
+		require.Equal(t, "values", app.Spec.Sources[0].Ref)
+		require.NotNil(t, app.Spec.Sources[1].Helm)
+		require.Equal(t, []string{"$values/podinfo/values.yaml"}, app.Spec.Sources[1].Helm.ValueFiles)
+	})
+}
+
+func TestGetPatchedRepoURL(t *testing.T) {
+	state := testZarfState()
+	r := &v1.AdmissionRequest{Operation: v1.Create}
+	ctx := context.TODO()
+
+	t.Run("git source is rewritten through the git server", func(t *testing.T) {
+		source := ApplicationSource{RepoURL: "https://github.com/defenseunicorns/zarf.git"}
+		patched, err := getPatchedRepoURL(ctx, source, state, r)
+		require.NoError(t, err)
+		require.Contains(t, patched, "git.localhost")
+	})
+
+	t.Run("helm OCI source is rewritten through the registry", func(t *testing.T) {
+		source := ApplicationSource{RepoURL: "oci://ghcr.io/defenseunicorns/charts", Chart: "podinfo"}
+		patched, err := getPatchedRepoURL(ctx, source, state, r)
+		require.NoError(t, err)
+		require.Equal(t, "oci://registry.localhost/defenseunicorns/charts", patched)
+	})
+
+	t.Run("classic helm chart repo is left untouched", func(t *testing.T) {
+		source := ApplicationSource{RepoURL: "https://charts.example.com", Chart: "podinfo"}
+		patched, err := getPatchedRepoURL(ctx, source, state, r)
+		require.NoError(t, err)
+		require.Equal(t, "https://charts.example.com", patched)
+	})
+
+	t.Run("value-file ref source still gets its own repoURL patched", func(t *testing.T) {
+		source := ApplicationSource{RepoURL: "https://github.com/defenseunicorns/zarf-values.git", Ref: "values"}
+		patched, err := getPatchedRepoURL(ctx, source, state, r)
+		require.NoError(t, err)
+		require.Contains(t, patched, "git.localhost")
+	})
+}
+
+// patchValue returns the string value of the patch operation at path, failing the test if no such
+// patch was produced.
+func patchValue(t *testing.T, patches []operations.PatchOperation, path string) string {
+	t.Helper()
+	for _, p := range patches {
+		if p.Path == path {
+			value, ok := p.Value.(string)
+			require.Truef(t, ok, "patch value at %s is not a string: %#v", path, p.Value)
+			return value
+		}
+	}
+	t.Fatalf("no patch found for path %s", path)
+	return ""
+}
+
+func TestComputeApplicationPatches(t *testing.T) {
+	state := testZarfState()
+	r := &v1.AdmissionRequest{Operation: v1.Create}
+
+	t.Run("single-source helm is rewritten to the registry", func(t *testing.T) {
+		r.Object = runtime.RawExtension{Raw: []byte(singleSourceHelmOCIFixture)}
+		result, err := computeApplicationPatches(context.TODO(), r, state)
+		require.NoError(t, err)
+		require.Equal(t, "oci://registry.localhost/defenseunicorns/charts", patchValue(t, result.PatchOps, "/spec/source/repoURL"))
+	})
+
+	t.Run("multi-source git+helm rewrites each source through its own path", func(t *testing.T) {
+		r.Object = runtime.RawExtension{Raw: []byte(multiSourceGitHelmFixture)}
+		result, err := computeApplicationPatches(context.TODO(), r, state)
+		require.NoError(t, err)
+		require.Contains(t, patchValue(t, result.PatchOps, "/spec/sources/0/repoURL"), "git.localhost")
+		require.Equal(t, "oci://registry.localhost/defenseunicorns/charts", patchValue(t, result.PatchOps, "/spec/sources/1/repoURL"))
+	})
+
+	t.Run("value-file ref layout still patches the referenced source's own repoURL", func(t *testing.T) {
+		r.Object = runtime.RawExtension{Raw: []byte(valueFileRefFixture)}
+		result, err := computeApplicationPatches(context.TODO(), r, state)
+		require.NoError(t, err)
+		require.Contains(t, patchValue(t, result.PatchOps, "/spec/sources/0/repoURL"), "git.localhost")
+		require.Equal(t, "oci://registry.localhost/defenseunicorns/charts", patchValue(t, result.PatchOps, "/spec/sources/1/repoURL"))
+	})
+}
+
+func TestGetPatchedGitRepoURL_Idempotency(t *testing.T) {
+	state := testZarfState()
+	const transformedURL = "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08.git"
+
+	tt := []struct {
+		name      string
+		operation v1.Operation
+		repoURL   string
+		expected  string
+	}{
+		{
+			name:      "create mutates an untransformed upstream url",
+			operation: v1.Create,
+			repoURL:   "https://github.com/defenseunicorns/zarf.git",
+			expected:  transformedURL,
+		},
+		{
+			name:      "update after the ArgoCD controller writes back a normalized url is left alone",
+			operation: v1.Update,
+			repoURL:   "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08/",
+			expected:  "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08/",
+		},
+		{
+			name:      "update after the ArgoCD controller drops the .git suffix is left alone",
+			operation: v1.Update,
+			repoURL:   "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08",
+			expected:  "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08",
+		},
+		{
+			name:      "update with a changed upstream repo is re-mutated",
+			operation: v1.Update,
+			repoURL:   "https://github.com/defenseunicorns/some-other-repo.git",
+			expected:  "https://git.localhost/zarf-git-user/defenseunicorns-some-other-repo-e4970ce8.git",
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			r := &v1.AdmissionRequest{Operation: tc.operation}
+			patched, err := getPatchedGitRepoURL(tc.repoURL, state, r)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, patched)
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2021-Present The Zarf Authors
+
+// Package hooks contains the mutation hooks for the Zarf agent.
+package hooks
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+
+	"github.com/defenseunicorns/zarf/src/config/lang"
+	"github.com/defenseunicorns/zarf/src/internal/agent/operations"
+	"github.com/defenseunicorns/zarf/src/pkg/cluster"
+	"github.com/defenseunicorns/zarf/src/pkg/message"
+	"github.com/defenseunicorns/zarf/src/pkg/transform"
+	v1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// argoCDSecretTypeLabel is the label ArgoCD uses to identify a Secret as holding repository (or
+// repo-creds) credentials. See https://argo-cd.readthedocs.io/en/stable/operator-manual/declarative-setup/#repositories
+const argoCDSecretTypeLabel = "argocd.argoproj.io/secret-type"
+
+// agentCredModeAnnotation lets a Repository Secret opt into push (read/write) credentials instead of
+// the pull (read-only) credentials that are used by default.
+const agentCredModeAnnotation = "zarf.dev/agent-cred-mode"
+
+// agentCredModePush is the agentCredModeAnnotation value that requests push credentials.
+const agentCredModePush = "push"
+
+// NewRepositoryMutationHook creates a new instance of the ArgoCD Repository Secret mutation hook.
+func NewRepositoryMutationHook(ctx context.Context, cluster *cluster.Cluster) operations.Hook {
+	message.Debug("hooks.NewRepositoryMutationHook()")
+	return operations.Hook{
+		Create: func(r *v1.AdmissionRequest) (*operations.Result, error) {
+			return mutateRepositorySecret(ctx, r, cluster)
+		},
+		Update: func(r *v1.AdmissionRequest) (*operations.Result, error) {
+			return mutateRepositorySecret(ctx, r, cluster)
+		},
+	}
+}
+
+// mutateRepositorySecret mutates the url/username/password of an ArgoCD repository Secret to point at
+// the Zarf git server defined in the ZarfState, so that ArgoCD authenticates against the in-cluster
+// mirror instead of the original upstream repository. repo-creds Secrets, and Secrets without either
+// label value, are allowed through unchanged.
+func mutateRepositorySecret(ctx context.Context, r *v1.AdmissionRequest, cluster *cluster.Cluster) (*operations.Result, error) {
+	secret := corev1.Secret{}
+	if err := json.Unmarshal(r.Object.Raw, &secret); err != nil {
+		return nil, fmt.Errorf(lang.ErrUnmarshal, err)
+	}
+
+	// Only repository Secrets carry a concrete repo URL. repo-creds Secrets' `url` is a *prefix* matched
+	// against many repositories, not a repo URL itself -- transforming it would append a repo-specific
+	// checksum suffix that then fails to match any of the (separately transformed) repo URLs it's meant
+	// to provide credentials for, so it is left untouched here rather than handled like `repository`.
+	switch secretType := secret.Labels[argoCDSecretTypeLabel]; secretType {
+	case "repository":
+	case "repo-creds":
+		message.Debugf("ArgoCD Secret (%s) is a repo-creds url prefix, skipping mutation", secret.Name)
+		return &operations.Result{Allowed: true}, nil
+	default:
+		return &operations.Result{Allowed: true}, nil
+	}
+
+	// ArgoCD repository Secrets default to type "git" when the field is omitted, and also use
+	// "helm"/"oci" for Helm chart repositories. We only know how to rewrite git credentials onto the
+	// Zarf git server here, so leave non-git (Helm/OCI) repository Secrets untouched.
+	switch repoType := string(secret.Data["type"]); repoType {
+	case "", "git":
+	default:
+		message.Debugf("ArgoCD Repository Secret (%s) has type (%s), skipping mutation", secret.Name, repoType)
+		return &operations.Result{Allowed: true}, nil
+	}
+
+	url := string(secret.Data["url"])
+	if url == "" {
+		return &operations.Result{Allowed: true}, nil
+	}
+
+	state, err := cluster.LoadZarfState(ctx)
+	if err != nil {
+		return nil, fmt.Errorf(lang.AgentErrGetState, err)
+	}
+
+	message.Debugf("Using the url of (%s) to mutate the ArgoCD Repository Secret", state.GitServer.Address)
+
+	// Mirror the idempotency check getPatchedGitRepoURL uses for Applications: on Update, only mutate if
+	// the url hasn't already been transformed to point at the Zarf git server, otherwise an unrelated
+	// field update (e.g. flipping agentCredModeAnnotation) would re-run transform.GitURL against an
+	// already-transformed url and double-transform it.
+	if r.Operation == v1.Update {
+		isPatched, err := transform.IsGitURLTransformed(state, url)
+		if err != nil {
+			return nil, fmt.Errorf("unable to determine if the Repository Secret url (%s) is already transformed: %w", url, err)
+		}
+		if isPatched {
+			return &operations.Result{Allowed: true}, nil
+		}
+	}
+
+	transformedURL, err := transform.GitURL(state.GitServer.Address, url, state.GitServer.PushUsername)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", AgentErrTransformGitURL, err)
+	}
+
+	username := state.GitServer.PullUsername
+	password := state.GitServer.PullPassword
+	if secret.Annotations[agentCredModeAnnotation] == agentCredModePush {
+		username = state.GitServer.PushUsername
+		password = state.GitServer.PushPassword
+	}
+
+	patches := []operations.PatchOperation{
+		operations.ReplacePatchOperation("/data/url", encodeSecretValue(transformedURL.String())),
+		// username/password may not exist on the original Secret (e.g. SSH-key auth, or a public
+		// HTTP(S) repo with no credentials), and "replace" requires the path to already exist, so these
+		// use "add" semantics, which create the key if absent and replace it otherwise.
+		operations.AddPatchOperation("/data/username", encodeSecretValue(username)),
+		operations.AddPatchOperation("/data/password", encodeSecretValue(password)),
+	}
+
+	return &operations.Result{
+		Allowed:  true,
+		PatchOps: patches,
+	}, nil
+}
+
+// encodeSecretValue base64 encodes a value for use in a JSON patch against a Secret's `data` map,
+// matching how Kubernetes serializes `[]byte` Secret data on the wire.
+func encodeSecretValue(value string) string {
+	return base64.StdEncoding.EncodeToString([]byte(value))
+}
@@ -0,0 +1,25 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2021-Present The Zarf Authors
+
+// Package agent contains the mutating webhook server for the Zarf agent.
+package agent
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/defenseunicorns/zarf/src/internal/agent/hooks"
+	"github.com/defenseunicorns/zarf/src/pkg/cluster"
+)
+
+// NewServer creates a new http.ServeMux registering the Zarf agent's mutating webhook routes.
+func NewServer(ctx context.Context, c *cluster.Cluster) *http.ServeMux {
+	mux := http.NewServeMux()
+
+	mux.Handle("/mutate/argocd-application", hooks.Admission(hooks.NewApplicationMutationHook(ctx, c)))
+	// Secret create/update events are filtered inside the hook itself: only ArgoCD repository Secrets
+	// (argocd.argoproj.io/secret-type=repository) are mutated; repo-creds and other Secrets pass through unchanged.
+	mux.Handle("/mutate/argocd-repository", hooks.Admission(hooks.NewRepositoryMutationHook(ctx, c)))
+
+	return mux
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2021-Present The Zarf Authors
+
+// Package transform provides helper functions to transform URLs to point to Zarf state data.
+package transform
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/defenseunicorns/pkg/helpers"
+	"github.com/defenseunicorns/zarf/src/types"
+)
+
+// zarfGitURLChecksumPattern matches the `-<checksum>` suffix Zarf appends to a repository name when
+// mirroring it into the Zarf git server, e.g. "mudler-helm-charts-2698eb08". The checksum length isn't
+// pinned to a single hash implementation here -- only that a hex suffix was appended -- so this stays
+// correct if the checksum algorithm or its length changes.
+var zarfGitURLChecksumPattern = regexp.MustCompile(`-[0-9a-f]{4,}$`)
+
+// IsGitURLTransformed reports whether gitURL has already been rewritten to point at the Zarf git
+// server defined in state, i.e. the hostname matches and the path is of the form
+// `/<push-user>/<repo>-<checksum>` (with or without a trailing slash or `.git` suffix).
+//
+// This is the canonical check used to make mutation idempotent: comparing hostnames alone is not
+// enough, since ArgoCD's application controller can write the repoURL back with a normalized trailing
+// slash or `.git` suffix, which would otherwise look unmutated and get transformed a second time.
+func IsGitURLTransformed(state *types.ZarfState, gitURL string) (bool, error) {
+	hostMatches, err := helpers.DoHostnamesMatch(state.GitServer.Address, gitURL)
+	if err != nil {
+		return false, err
+	}
+	if !hostMatches {
+		return false, nil
+	}
+
+	parsed, err := url.Parse(gitURL)
+	if err != nil {
+		return false, err
+	}
+
+	path := strings.TrimSuffix(strings.TrimSuffix(parsed.Path, "/"), ".git")
+	path = strings.TrimPrefix(path, "/")
+
+	pushUser, repo, found := strings.Cut(path, "/")
+	if !found || pushUser != state.GitServer.PushUsername {
+		return false, nil
+	}
+
+	return zarfGitURLChecksumPattern.MatchString(repo), nil
+}
+
+// IsOCIRegistryRefTransformed reports whether ref (an `oci://` Helm chart reference) has already been
+// rewritten to point at the Zarf registry defined in state.
+//
+// Unlike a mirrored git repository, a mirrored OCI ref isn't given a checksum-suffixed path -- Zarf's
+// registry mirror preserves the original repository path under the new host -- so hostname equality is
+// the canonical signal here. This is still routed through the same DoHostnamesMatch-based comparison
+// IsGitURLTransformed uses (rather than a raw string compare) so that a registry controller writing the
+// ref back with trivial formatting differences (e.g. a trailing slash) is still recognized as
+// already-transformed and isn't mutated a second time.
+func IsOCIRegistryRefTransformed(state *types.ZarfState, ref string) (bool, error) {
+	// Both sides carry the oci:// scheme so that url.Parse reads their host (a scheme-less
+	// "host/path" or "host:port" would otherwise have no hostname, or fail to parse).
+	trimmed := strings.TrimPrefix(ref, "oci://")
+	return helpers.DoHostnamesMatch("oci://"+state.RegistryInfo.Address, "oci://"+trimmed)
+}
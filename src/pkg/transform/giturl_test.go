@@ -0,0 +1,106 @@
+// SPDX-License-Identifier: Apache-2.0
+// SPDX-FileCopyrightText: 2021-Present The Zarf Authors
+
+package transform
+
+import (
+	"testing"
+
+	"github.com/defenseunicorns/zarf/src/types"
+	"github.com/stretchr/testify/require"
+)
+
+func testState() *types.ZarfState {
+	return &types.ZarfState{
+		GitServer: types.GitServerInfo{
+			Address:      "https://git.localhost",
+			PushUsername: "zarf-git-user",
+		},
+		RegistryInfo: types.RegistryInfo{
+			Address: "registry.localhost",
+		},
+	}
+}
+
+func TestIsGitURLTransformed(t *testing.T) {
+	state := testState()
+
+	tt := []struct {
+		name     string
+		gitURL   string
+		expected bool
+	}{
+		{
+			name:     "untransformed upstream url",
+			gitURL:   "https://github.com/defenseunicorns/zarf.git",
+			expected: false,
+		},
+		{
+			name:     "freshly transformed url",
+			gitURL:   "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08.git",
+			expected: true,
+		},
+		{
+			name:     "controller writeback with trailing slash",
+			gitURL:   "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08/",
+			expected: true,
+		},
+		{
+			name:     "controller writeback without .git suffix",
+			gitURL:   "https://git.localhost/zarf-git-user/defenseunicorns-zarf-2698eb08",
+			expected: true,
+		},
+		{
+			name:     "matching host but missing checksum suffix",
+			gitURL:   "https://git.localhost/zarf-git-user/defenseunicorns-zarf.git",
+			expected: false,
+		},
+		{
+			name:     "matching host but different push user",
+			gitURL:   "https://git.localhost/someone-else/defenseunicorns-zarf-2698eb08.git",
+			expected: false,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := IsGitURLTransformed(state, tc.gitURL)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}
+
+func TestIsOCIRegistryRefTransformed(t *testing.T) {
+	state := testState()
+
+	tt := []struct {
+		name     string
+		ref      string
+		expected bool
+	}{
+		{
+			name:     "untransformed upstream ref",
+			ref:      "oci://ghcr.io/defenseunicorns/charts/zarf",
+			expected: false,
+		},
+		{
+			name:     "freshly transformed ref",
+			ref:      "oci://registry.localhost/defenseunicorns/charts/zarf",
+			expected: true,
+		},
+		{
+			name:     "controller writeback with trailing slash",
+			ref:      "oci://registry.localhost/defenseunicorns/charts/zarf/",
+			expected: true,
+		},
+	}
+
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			actual, err := IsOCIRegistryRefTransformed(state, tc.ref)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, actual)
+		})
+	}
+}